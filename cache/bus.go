@@ -0,0 +1,69 @@
+package cache
+
+import "sync"
+
+// ResetKey is the sentinel [InvalidateMsg.Key] meaning "reset the whole cache",
+// as published by [Component.Reset].
+const ResetKey = "*"
+
+// InvalidateMsg is broadcast over a [Bus] when a cache entry, or the whole cache,
+// is invalidated, so that other processes sharing the same logical cache can stay
+// in sync.
+type InvalidateMsg struct {
+	// Origin identifies the process that published this message, so a subscriber
+	// can ignore its own publishes.
+	Origin string
+	// Key is the cache key to remove, or ResetKey to reset the whole cache.
+	Key string
+}
+
+// Bus lets multiple cache instances, such as separate processes behind a load
+// balancer, propagate invalidation to each other. Supplying one with [WithBus]
+// makes [Component.Remove] and [Component.Reset] publish to it, and subscribes so
+// that invalidations published by peers are applied locally too.
+type Bus interface {
+	// Publish broadcasts msg to every subscriber, which may include the
+	// publisher's own subscription; subscribers use [InvalidateMsg.Origin] to
+	// skip their own messages.
+	Publish(msg InvalidateMsg) error
+	// Subscribe registers handler to be called for every message published on
+	// the Bus, including those from the same origin, for as long as the Bus
+	// exists.
+	Subscribe(handler func(InvalidateMsg)) error
+}
+
+// LocalBus is an in-process [Bus]. It's mainly useful for tests, or for wiring
+// together multiple caches within the same process; every message published is
+// delivered synchronously to every subscribed handler.
+type LocalBus struct {
+	mu       sync.Mutex
+	handlers []func(InvalidateMsg)
+}
+
+// NewLocalBus creates an empty [LocalBus].
+func NewLocalBus() *LocalBus {
+	return &LocalBus{}
+}
+
+// Publish implements [Bus].
+func (b *LocalBus) Publish(msg InvalidateMsg) error {
+	b.mu.Lock()
+	handlers := append([]func(InvalidateMsg){}, b.handlers...)
+	b.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(msg)
+	}
+
+	return nil
+}
+
+// Subscribe implements [Bus].
+func (b *LocalBus) Subscribe(handler func(InvalidateMsg)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, handler)
+
+	return nil
+}
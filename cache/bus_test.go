@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBusPropagatesInvalidation(t *testing.T) {
+	bus := NewLocalBus()
+
+	builderA := New(WithBus(bus))
+	builderB := New(WithBus(bus))
+
+	ctlA := builderA("")
+	ctlB := builderB("")
+
+	storeA := ctlA.store.(*lru)
+	storeB := ctlB.store.(*lru)
+
+	storeA.Set("shared", []byte("value"), time.Minute)
+	storeB.Set("shared", []byte("value"), time.Minute)
+
+	ctlA.Remove("shared")
+
+	_, okA := storeA.Get("shared")
+	_, okB := storeB.Get("shared")
+	equals(t, false, okA)
+	equals(t, false, okB)
+}
+
+func TestBusPropagatesReset(t *testing.T) {
+	bus := NewLocalBus()
+
+	builderA := New(WithBus(bus))
+	builderB := New(WithBus(bus))
+
+	ctlA := builderA("")
+	ctlB := builderB("")
+
+	ctlA.store.(*lru).Set("a", []byte("A"), time.Minute)
+	ctlB.store.(*lru).Set("b", []byte("B"), time.Minute)
+
+	ctlA.Reset()
+
+	equals(t, 0, ctlA.Stats().Items)
+	equals(t, 0, ctlB.Stats().Items)
+}
+
+// closableBus is a [Bus] that also implements the unexported closer interface,
+// like [RedisBus], so TestCloseClosesBus can verify [Component.Close] reaches it.
+type closableBus struct {
+	*LocalBus
+	closed bool
+}
+
+func (b *closableBus) Close() {
+	b.closed = true
+}
+
+func TestCloseClosesBus(t *testing.T) {
+	bus := &closableBus{LocalBus: NewLocalBus()}
+	builder := New(WithBus(bus))
+	ctl := builder("")
+
+	ctl.Close()
+
+	assert(t, bus.closed, "expected Component.Close to close a Bus implementing closer")
+}
+
+func TestBusRemoveLeavesOtherKeys(t *testing.T) {
+	bus := NewLocalBus()
+	builder := New(WithBus(bus))
+	ctl := builder("")
+
+	store := ctl.store.(*lru)
+	store.Set("self", []byte("value"), time.Minute)
+
+	ctl.Remove("other-key")
+
+	_, ok := store.Get("self")
+	equals(t, true, ok)
+}
@@ -49,13 +49,41 @@
 //
 // Cache instances (created with [New]) are independent. They don't share any memory and may
 // have different settings.
+//
+// # Negative caching
+//
+// By default, a child that fails to render is not cached, so a broken upstream
+// data source gets re-rendered on every request, and an empty body is cached
+// like any other output. [WithNegativeTTL] instead records a lightweight
+// tombstone for a key whose child errors or renders an empty body, and replays
+// the same error (or empty body) for a configurable duration:
+//
+//	var cache = New(WithNegativeTTL(10 * time.Second))
+//
+// # Storage backends
+//
+// By default, cached output is kept in an in-process LRU. Supplying [WithStore] replaces
+// this with any implementation of [Store], including the [RedisStore] and [MemcacheStore]
+// adapters in this package, so that cached output can be shared across processes:
+//
+//	var cache = New(WithStore(NewRedisStore(redisClient, "myapp:")))
+//
+// For a single process under heavy concurrent traffic, [NewSharded] is a drop-in
+// in-process alternative to the default LRU that spreads entries across several
+// independent shards to reduce lock contention:
+//
+//	var cache = New(WithStore(NewSharded(512*1024*1024, 0)))
 package cache
 
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"math"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/a-h/templ"
@@ -64,12 +92,54 @@ import (
 const defaultTTL = time.Duration(5 * time.Minute)
 const defaultMem = 64 * 1024 * 1024
 
+// Store is implemented by cache storage backends. The in-process LRU used by default,
+// as well as the [RedisStore] and [MemcacheStore] adapters, all implement Store, and a
+// custom implementation can be supplied with [WithStore].
+//
+// Rendered component output is always []byte, so the interface stays byte-oriented.
+// Implementations that enforce TTLs natively (such as Redis and memcached) are expected
+// to do so themselves rather than relying on the caller. Stores that can't report exact
+// memory usage should return -1 for the MaxMemory and UsedMemory fields of [Stats].
+type Store interface {
+	// Get returns the cached value for key, and true if it was found and isn't expired.
+	Get(key string) ([]byte, bool)
+	// Set stores value under key, to expire after ttl.
+	Set(key string, value []byte, ttl time.Duration)
+	// Delete removes the cached value for key, if any.
+	Delete(key string)
+	// Reset clears all cached values and resets statistics. A backend with no
+	// efficient way to enumerate its own keys (such as [MemcacheStore], which
+	// has no SCAN-equivalent) may only be able to reset its statistics,
+	// leaving existing entries to expire via their own TTL; see that
+	// implementation's doc comment for specifics.
+	Reset()
+	// Stats reports usage statistics for the store.
+	Stats() Stats
+}
+
 // Component is the cache component for use in templates.
 type Component struct {
-	ttl         time.Duration
-	key         string
-	initialized bool
-	lru         *lru
+	ttl           time.Duration
+	key           string
+	initialized   bool
+	store         Store
+	storeExplicit bool // true once WithStore has installed a store; see WithMaxMemory/WithTwoQueue
+	disabled      *atomic.Bool
+	coalesce      bool
+	flight        *flightGroup
+	staleWindow   time.Duration
+	negativeTTL   time.Duration
+	keyStats      *sync.Map // map[string]*keyCounter, nil unless WithKeyLabels(true)
+	bus           Bus
+	origin        string
+}
+
+// originSeq distinguishes builders created in quick succession within the same
+// process, since they'd otherwise share a PID-based origin ID.
+var originSeq int64
+
+func newOrigin() string {
+	return fmt.Sprintf("%d.%d", os.Getpid(), atomic.AddInt64(&originSeq, 1))
 }
 
 type Option func(c *Component)
@@ -81,11 +151,15 @@ type ComponentBuilder func(key string, opts ...Option) Component
 
 // New creates a cache and returns a builder function
 // that can be used in templates. It accepts zero or more functional
-// options (WithTTL(), WithMaxMemory()).
+// options (WithTTL(), WithMaxMemory(), WithStore()).
 func New(opts ...Option) ComponentBuilder {
 	base := Component{
-		ttl: defaultTTL,
-		lru: newLRU(defaultMem),
+		ttl:      defaultTTL,
+		store:    newLRU(defaultMem),
+		disabled: &atomic.Bool{},
+		coalesce: true,
+		flight:   newFlightGroup(),
+		origin:   newOrigin(),
 	}
 
 	for _, opt := range opts {
@@ -93,6 +167,23 @@ func New(opts ...Option) ComponentBuilder {
 	}
 	base.initialized = true
 
+	// Subscribe once per builder, applying invalidations published by peers to
+	// the shared store. Messages this builder published itself are ignored by
+	// matching on origin.
+	if base.bus != nil {
+		store, origin := base.store, base.origin
+		base.bus.Subscribe(func(msg InvalidateMsg) {
+			if msg.Origin == origin {
+				return
+			}
+			if msg.Key == ResetKey {
+				store.Reset()
+				return
+			}
+			store.Delete(msg.Key)
+		})
+	}
+
 	return func(key string, opts ...Option) Component {
 		dupe := base
 		dupe.key = key
@@ -114,12 +205,36 @@ func WithTTL(d time.Duration) Option {
 }
 
 // WithMaxMemory sets the maximum memory (in bytes) used for the cache.
-// Note that this will be ignored when set on individual components. If
-// the size is 0 then there is no memory limit.
+// Note that this will be ignored when set on individual components, or when
+// [WithStore] is used anywhere in the same option list, regardless of order.
+// If the size is 0 then there is no memory limit.
 func WithMaxMemory(maxBytes int) Option {
 	return func(c *Component) {
 		// This can't be changed after initialization
-		if c.initialized {
+		if c.initialized || c.storeExplicit {
+			return
+		}
+
+		if maxBytes == 0 {
+			maxBytes = math.MaxInt
+		}
+
+		c.store = newLRU(maxBytes)
+	}
+}
+
+// WithTwoQueue replaces the default LRU with a [twoQueue], which keeps a second
+// "ghost" list of recently evicted keys so that a key evicted once and then
+// requested again is promoted straight to the protected, frequently-used part
+// of the cache. This avoids the classic LRU weakness where a single scan
+// through many keys, each touched once, evicts a working set of keys that are
+// actually accessed repeatedly. Like [WithMaxMemory], note that this will be
+// ignored when set on individual components, or when [WithStore] is used
+// anywhere in the same option list, regardless of order.
+func WithTwoQueue(maxBytes int) Option {
+	return func(c *Component) {
+		// This can't be changed after initialization
+		if c.initialized || c.storeExplicit {
 			return
 		}
 
@@ -127,55 +242,331 @@ func WithMaxMemory(maxBytes int) Option {
 			maxBytes = math.MaxInt
 		}
 
-		c.lru = newLRU(maxBytes)
+		c.store = newTwoQueue(maxBytes)
+	}
+}
+
+// WithCoalesce controls whether concurrent renders for the same key, following a
+// cache miss, are coalesced into a single render (the default). When enabled, the
+// first goroutine to miss on a key renders its children once; any other goroutine
+// that misses on the same key while that render is in progress waits for it and
+// reuses the result, instead of rendering (and caching) the same output again.
+func WithCoalesce(enabled bool) Option {
+	return func(c *Component) {
+		c.coalesce = enabled
+	}
+}
+
+// WithStore replaces the default in-process LRU with another implementation of [Store],
+// such as [NewRedisStore] or [NewMemcacheStore], so that cached output can be shared
+// across processes. Note that this will be ignored when set on individual components.
+//
+// WithStore always wins over [WithMaxMemory] or [WithTwoQueue] in the same option
+// list, regardless of the order they're passed in: once a store has been supplied
+// explicitly, those two become no-ops instead of silently replacing it.
+func WithStore(store Store) Option {
+	return func(c *Component) {
+		// This can't be changed after initialization
+		if c.initialized {
+			return
+		}
+
+		c.store = store
+		c.storeExplicit = true
+	}
+}
+
+// WithBus supplies a [Bus] so that Component.Remove and Component.Reset propagate
+// to other processes sharing this cache (e.g. multiple instances behind a load
+// balancer), and so that invalidations published by those peers are applied here
+// in turn. Note that this will be ignored when set on individual components.
+func WithBus(bus Bus) Option {
+	return func(c *Component) {
+		// This can't be changed after initialization
+		if c.initialized {
+			return
+		}
+
+		c.bus = bus
+	}
+}
+
+// WithStaleWhileRevalidate allows an entry to keep being served for up to d past its
+// TTL, while a single background render (coalesced the same way as [WithCoalesce])
+// refreshes it. This avoids the latency spike a high-traffic component would otherwise
+// see on every TTL expiry. It requires a [Store] that also implements the stale-serving
+// methods of the in-process LRU (SetStale and GetStale); stores that don't support it
+// (such as [RedisStore] and [MemcacheStore]) fall back to ordinary TTL expiration.
+func WithStaleWhileRevalidate(d time.Duration) Option {
+	return func(c *Component) {
+		c.staleWindow = d
+	}
+}
+
+// swrStore is implemented by stores that support stale-while-revalidate serving, as
+// used by [WithStaleWhileRevalidate]. The in-process LRU implements it; external
+// stores don't have to.
+type swrStore interface {
+	Store
+	SetStale(key string, value []byte, ttl, staleWindow time.Duration)
+	GetStale(key string) (value []byte, stale bool, found bool)
+}
+
+// negativeStore is implemented by stores that support negative caching (see
+// [WithNegativeTTL]). The in-process LRU implements it; external stores don't
+// have to.
+type negativeStore interface {
+	Store
+	SetError(key string, err error, ttl time.Duration)
+	SetEmpty(key string, ttl time.Duration)
+	GetTombstone(key string) (err error, isTombstone bool)
+}
+
+// WithNegativeTTL enables negative caching: for ttl after a child render returns
+// an error, or renders an empty body, Render replays that outcome (the same
+// error, or nothing) instead of re-rendering. The tombstone recorded is a small,
+// fixed size regardless of the length of the error message, so a broken
+// upstream data source can't turn repeated failures into unbounded memory
+// growth, while still getting the same protection from render storms that an
+// ordinary cache hit gives. It requires a [Store] that also implements the
+// tombstone methods of the in-process LRU (SetError, SetEmpty and
+// GetTombstone); with a store that doesn't (such as [RedisStore] and
+// [MemcacheStore]), an erroring child is re-rendered on every request and an
+// empty body is cached as an ordinary value.
+func WithNegativeTTL(d time.Duration) Option {
+	return func(c *Component) {
+		c.negativeTTL = d
+	}
+}
+
+// WithOnEvict registers a callback invoked for every entry removed from the cache,
+// with its value and why (see [EvictReason]). It is only honored when the
+// cache's [Store] is the default in-process LRU; it's ignored for external
+// stores such as [RedisStore] and [MemcacheStore], which don't report individual
+// evictions. The callback is always invoked outside of any internal lock, so it
+// may safely read [Component.Stats] or otherwise call back into the cache. Note
+// that it is not called for entries cleared by Reset. See also [Stats.Evictions]
+// and [Stats.Expirations], which count evictions by reason without requiring a
+// callback.
+func WithOnEvict(fn func(key string, value []byte, reason EvictReason)) Option {
+	return func(c *Component) {
+		if l, ok := asLRU(c.store); ok {
+			l.onEvict = fn
+		}
+	}
+}
+
+// WithJanitor wraps the in-process LRU with a background goroutine that
+// proactively reclaims expired entries every d, instead of only lazily on read
+// or when memory pressure forces an eviction sweep. This matters for a cache
+// that holds many expired-but-small entries and sees little traffic, since
+// those would otherwise sit in memory indefinitely. It's ignored unless the
+// store is a plain [*lru] at the point this option runs, so apply it after
+// [WithMaxMemory] if both are used; it's also ignored after [WithStore] or
+// [WithTwoQueue], since the janitor is specific to the default LRU.
+//
+// The returned cache's [Component.Close] method stops the janitor; it's safe to
+// never call, since the goroutine also stops on its own once the cache itself
+// becomes unreachable.
+func WithJanitor(d time.Duration) Option {
+	return func(c *Component) {
+		if c.initialized {
+			return
+		}
+
+		l, ok := c.store.(*lru)
+		if !ok {
+			return
+		}
+
+		c.store = wrapWithJanitor(l, d)
+	}
+}
+
+// closer is implemented by a [Store] or [Bus] with a background goroutine that
+// needs explicit shutdown, such as the janitor-wrapped LRU from [WithJanitor]
+// or [RedisBus]'s subscription loop.
+type closer interface {
+	Close()
+}
+
+// Close stops any background goroutine owned by the cache's [Store] or [Bus],
+// such as the janitor started by [WithJanitor] or the subscription loop behind
+// [WithBus]. It's safe to call on a cache that has neither. Omitting it
+// entirely is only safe for a store whose goroutine also arranges to stop on
+// its own once it's garbage collected, such as the janitor; [RedisBus] has no
+// such fallback and will leak its subscription until Close is called.
+func (c Component) Close() {
+	if cl, ok := c.store.(closer); ok {
+		cl.Close()
+	}
+	if cl, ok := c.bus.(closer); ok {
+		cl.Close()
+	}
+}
+
+// keyCounter tracks reads and hits for a single cache key, used by [WithKeyLabels].
+type keyCounter struct {
+	reads int64
+	hits  int64
+}
+
+// WithKeyLabels enables per-key read/hit counters, exposed as Prometheus labels by
+// [NewPrometheusCollector]. It is off by default, since a cache with a large or
+// unbounded keyspace would otherwise blow up label cardinality. Note that this
+// will be ignored when set on individual components.
+func WithKeyLabels(enabled bool) Option {
+	return func(c *Component) {
+		// This can't be changed after initialization
+		if c.initialized {
+			return
+		}
+
+		if enabled {
+			c.keyStats = &sync.Map{}
+		} else {
+			c.keyStats = nil
+		}
+	}
+}
+
+// recordKey updates the per-key counters for c.key, if [WithKeyLabels] is enabled.
+func (c Component) recordKey(hit bool) {
+	if c.keyStats == nil {
+		return
+	}
+
+	v, _ := c.keyStats.LoadOrStore(c.key, &keyCounter{})
+	kc := v.(*keyCounter)
+
+	atomic.AddInt64(&kc.reads, 1)
+	if hit {
+		atomic.AddInt64(&kc.hits, 1)
 	}
 }
 
 type Stats struct {
-	MaxMemory  int // maximum configured memory
-	UsedMemory int // memory used by cached items (including expired but not deleted items)
-	Items      int // cached item count (including expired but not deleted items)
-	Reads      int // total cache reads
-	Hits       int // total cache hits
+	MaxMemory   int // maximum configured memory, or -1 if the store can't report it
+	UsedMemory  int // memory used by cached items, or -1 if the store can't report it
+	Items       int // cached item count, or -1 if the store can't report it
+	Reads       int // total cache reads
+	Hits        int // total cache hits
+	StaleHits   int // hits served from the stale-while-revalidate window, see WithStaleWhileRevalidate
+	Tombstones  int // negative-caching entries currently stored, see WithNegativeTTL
+	Evictions   int // entries removed under memory pressure (EvictLRU), or -1 if the store can't report it
+	Expirations int // entries removed for being past their TTL (EvictExpired), or -1 if the store can't report it
 }
 
 // Stats returns basic cache statistics. These will be reset with Reset().
 func (c Component) Stats() Stats {
-	l := c.lru
-
-	return Stats{
-		MaxMemory:  l.maxMem,
-		UsedMemory: l.mem,
-		Items:      l.list.Len(),
-		Reads:      l.reads,
-		Hits:       l.hits,
-	}
+	return c.store.Stats()
 }
 
 // Remove removes/invalidates the cached data for associated with key, if it exists.
+// If the cache was created with [WithBus], this also propagates to peers.
 func (c Component) Remove(key string) {
-	c.lru.deleteKey(key)
+	c.store.Delete(key)
+	c.publish(key)
+}
+
+// loaderStore is implemented by stores that can coalesce concurrent loads for a
+// missing key into one, as used by [Component.GetOrLoad]. The in-process LRU
+// implements it; external stores don't have to.
+type loaderStore interface {
+	Store
+	GetOrLoad(key string, ttl time.Duration, loader func() ([]byte, error)) ([]byte, error)
+}
+
+// GetOrLoad returns the cached value for key if present, otherwise calls loader
+// and caches its result under key for ttl before returning it. Unlike Render,
+// which caches a fixed child component, this is meant for use outside of a
+// template, wherever an expensive, cacheable value is computed directly.
+//
+// If the cache's [Store] supports it (as the default in-process LRU does),
+// concurrent calls for the same missing key are coalesced into a single loader
+// call; stores that don't support it (such as [RedisStore] and [MemcacheStore])
+// still return the correct result, just without that stampede protection.
+func (c Component) GetOrLoad(key string, ttl time.Duration, loader func() ([]byte, error)) ([]byte, error) {
+	if ls, ok := c.store.(loaderStore); ok {
+		return ls.GetOrLoad(key, ttl, loader)
+	}
+
+	if value, ok := c.store.Get(key); ok {
+		return value, nil
+	}
+
+	value, err := loader()
+	if err != nil {
+		return nil, err
+	}
+
+	c.store.Set(key, value, ttl)
+
+	return value, nil
 }
 
 // Disable will turn off (or back on) caching. This also has the effect of wiping the cache.
 func (c *Component) Disable(disable bool) {
 	if disable {
-		c.lru.reset()
+		c.store.Reset()
 	}
 
-	c.lru.disabled = disable
+	c.disabled.Store(disable)
 }
 
-// Reset erases the cache and resets statistics.
+// Reset erases the cache and resets statistics. If the cache was created with
+// [WithBus], this also propagates to peers.
 func (c *Component) Reset() {
-	c.lru.reset()
+	c.store.Reset()
+	c.publish(ResetKey)
+}
+
+// publish broadcasts an invalidation for key over c.bus, if one is configured.
+func (c Component) publish(key string) {
+	if c.bus == nil {
+		return
+	}
+
+	c.bus.Publish(InvalidateMsg{Origin: c.origin, Key: key})
 }
 
 // Render will render child components, using cached data and caching results as needed.
 func (c Component) Render(ctx context.Context, w io.Writer) error {
-	if cc, isCached := c.lru.get(c.key); isCached {
-		_, err := w.Write(cc)
-		return err
+	if !c.disabled.Load() {
+		if ns, supportsNegative := c.store.(negativeStore); c.negativeTTL > 0 && supportsNegative {
+			if err, isTombstone := ns.GetTombstone(c.key); isTombstone {
+				c.recordKey(true)
+				return err
+			}
+		}
+
+		if ss, supportsSWR := c.store.(swrStore); c.staleWindow > 0 && supportsSWR {
+			cc, stale, found := ss.GetStale(c.key)
+			c.recordKey(found)
+
+			if found {
+				if _, err := w.Write(cc); err != nil {
+					return err
+				}
+
+				// Refresh in the background; the caller already has its answer.
+				if stale {
+					if children := templ.GetChildren(ctx); children != nil {
+						go c.revalidate(children)
+					}
+				}
+
+				return nil
+			}
+		} else {
+			cc, isCached := c.store.Get(c.key)
+			c.recordKey(isCached)
+
+			if isCached {
+				_, err := w.Write(cc)
+				return err
+			}
+		}
 	}
 
 	// Get children.
@@ -185,18 +576,112 @@ func (c Component) Render(ctx context.Context, w io.Writer) error {
 		return nil
 	}
 
-	// Render children to a buffer.
-	var buf bytes.Buffer
-	err := children.Render(ctx, &buf)
+	// Render children to a buffer, caching the result as needed. This is wrapped in a
+	// closure so it can be coalesced below: concurrent misses on the same key share a
+	// single render instead of each rendering (and caching) the same output.
+	render := func(ctx context.Context) ([]byte, error) {
+		var buf bytes.Buffer
+		if err := children.Render(ctx, &buf); err != nil {
+			c.saveError(err)
+			return nil, err
+		}
+
+		result := buf.Bytes()
+		if len(result) == 0 {
+			c.saveEmpty()
+		} else {
+			c.save(result)
+		}
+
+		return result, nil
+	}
+
+	var result []byte
+	var err error
+	if c.coalesce {
+		result, err = c.flight.do(ctx, c.key, render)
+	} else {
+		result, err = render(ctx)
+	}
 	if err != nil {
 		return err
 	}
 
-	// Cache the result.
-	c.lru.put(c.key, buf.Bytes(), c.ttl)
-
 	// Write the result to the output.
-	_, err = w.Write(buf.Bytes())
+	_, err = w.Write(result)
 
 	return err
 }
+
+// save persists a freshly rendered result, using stale-while-revalidate storage
+// when it's enabled and the store supports it.
+func (c Component) save(value []byte) {
+	if c.disabled.Load() {
+		return
+	}
+
+	if ss, ok := c.store.(swrStore); c.staleWindow > 0 && ok {
+		ss.SetStale(c.key, value, c.ttl, c.staleWindow)
+		return
+	}
+
+	c.store.Set(c.key, value, c.ttl)
+}
+
+// saveError records a negative-caching tombstone for a render error, so that
+// subsequent requests return the same error for c.negativeTTL instead of
+// repeating a failing render. It does nothing unless [WithNegativeTTL] is
+// configured and the store supports it.
+func (c Component) saveError(err error) {
+	if c.disabled.Load() || c.negativeTTL <= 0 {
+		return
+	}
+
+	if ns, ok := c.store.(negativeStore); ok {
+		ns.SetError(c.key, err, c.negativeTTL)
+	}
+}
+
+// saveEmpty records an empty render result: as a negative-caching tombstone
+// if [WithNegativeTTL] is configured and the store supports it, or otherwise
+// as an ordinary cached empty value.
+func (c Component) saveEmpty() {
+	if c.disabled.Load() {
+		return
+	}
+
+	if c.negativeTTL > 0 {
+		if ns, ok := c.store.(negativeStore); ok {
+			ns.SetEmpty(c.key, c.negativeTTL)
+			return
+		}
+	}
+
+	c.save(nil)
+}
+
+// revalidate re-renders children in the background, with a context detached from
+// the request that triggered it, and refreshes the cache with the result. It
+// shares the render-coalescing machinery with Render, so a request that misses
+// the same key while revalidation is in progress joins it rather than starting
+// a second render.
+func (c Component) revalidate(children templ.Component) {
+	render := func(ctx context.Context) ([]byte, error) {
+		var buf bytes.Buffer
+		if err := children.Render(ctx, &buf); err != nil {
+			return nil, err
+		}
+
+		result := buf.Bytes()
+		c.save(result)
+
+		return result, nil
+	}
+
+	if c.coalesce {
+		c.flight.do(context.Background(), c.key, render)
+		return
+	}
+
+	render(context.Background())
+}
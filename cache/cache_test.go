@@ -311,11 +311,11 @@ func timeIt(f func()) time.Duration {
 }
 
 func peekFront(c Component) string {
-	return c.lru.list.Front().Value.(*entry).key
+	return c.store.(*lru).list.Front().Value.(*entry).key
 }
 
 func peekBack(c Component) string {
-	return c.lru.list.Back().Value.(*entry).key
+	return c.store.(*lru).list.Back().Value.(*entry).key
 }
 
 /*
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"sync"
+)
+
+// call represents a render in flight, or already completed, for a single key.
+type call struct {
+	wg        sync.WaitGroup
+	value     []byte
+	err       error
+	leaderCtx context.Context
+}
+
+// flightGroup coalesces concurrent renders for the same key into a single call,
+// so that N goroutines missing the cache at the same time only render once. This
+// is the same shape as [golang.org/x/sync/singleflight.Group], kept local here so
+// Component doesn't need an extra dependency for it.
+//
+// Unlike singleflight, do is context-aware: a render that fails because the
+// leading goroutine's context was cancelled doesn't fail every follower. Instead,
+// any follower whose own context is still live retries the render as the new
+// leader, so one caller giving up doesn't abort the response for the others.
+type flightGroup struct {
+	mu       sync.Mutex
+	inflight map[string]*call
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{inflight: make(map[string]*call)}
+}
+
+// do calls fn(ctx) for key, unless a render for key is already in flight, in
+// which case it waits for that render to finish and returns its result
+// instead. If the in-flight render errored because its leader's ctx was
+// cancelled and the caller's own ctx is still live, do promotes the caller to
+// leader and retries fn with its ctx, rather than handing back the dead
+// leader's error.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(context.Context) ([]byte, error)) ([]byte, error) {
+	for {
+		g.mu.Lock()
+		if c, ok := g.inflight[key]; ok {
+			g.mu.Unlock()
+			c.wg.Wait()
+
+			if c.err != nil && c.leaderCtx.Err() != nil && ctx.Err() == nil {
+				continue // the leader's ctx was cancelled; promote ourselves and retry
+			}
+
+			return c.value, c.err
+		}
+
+		c := &call{leaderCtx: ctx}
+		c.wg.Add(1)
+		g.inflight[key] = c
+		g.mu.Unlock()
+
+		c.value, c.err = fn(ctx)
+		c.wg.Done()
+
+		g.mu.Lock()
+		delete(g.inflight, key)
+		g.mu.Unlock()
+
+		return c.value, c.err
+	}
+}
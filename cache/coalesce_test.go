@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/a-h/templ"
+)
+
+// slowChild is a templ.Component that counts how many times it was rendered
+// and takes some time to do so, to simulate an expensive component.
+type slowChild struct {
+	calls *int32
+	delay time.Duration
+	body  string
+}
+
+func (c slowChild) Render(ctx context.Context, w io.Writer) error {
+	atomic.AddInt32(c.calls, 1)
+	time.Sleep(c.delay)
+	_, err := io.WriteString(w, c.body)
+	return err
+}
+
+func TestCoalesce(t *testing.T) {
+	var calls int32
+	child := slowChild{calls: &calls, delay: 50 * time.Millisecond, body: "EXPENSIVE"}
+
+	builder := New(WithTTL(time.Minute))
+	comp := builder("expensive")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx := templ.WithChildren(context.Background(), child)
+			var buf bytes.Buffer
+			if err := comp.Render(ctx, &buf); err != nil {
+				t.Error(err)
+				return
+			}
+			equals(t, "EXPENSIVE", buf.String())
+		}()
+	}
+	wg.Wait()
+
+	equals(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// ctxAwareChild is a templ.Component that blocks until its context is done or
+// its release channel is closed, whichever comes first, so a test can force a
+// render to either observe cancellation or complete successfully.
+type ctxAwareChild struct {
+	calls   *int32
+	release <-chan struct{}
+	body    string
+}
+
+func (c ctxAwareChild) Render(ctx context.Context, w io.Writer) error {
+	atomic.AddInt32(c.calls, 1)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.release:
+	}
+
+	_, err := io.WriteString(w, c.body)
+	return err
+}
+
+func TestCoalescePromotesFollowerOnLeaderCancellation(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	child := ctxAwareChild{calls: &calls, release: release, body: "EXPENSIVE"}
+
+	builder := New(WithTTL(time.Minute))
+	comp := builder("expensive")
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+
+	leaderDone := make(chan error, 1)
+	go func() {
+		ctx := templ.WithChildren(leaderCtx, child)
+		leaderDone <- comp.Render(ctx, io.Discard)
+	}()
+
+	// Give the leader time to become the in-flight render before the follower
+	// joins it.
+	time.Sleep(10 * time.Millisecond)
+
+	followerDone := make(chan error, 1)
+	go func() {
+		ctx := templ.WithChildren(context.Background(), child)
+		var buf bytes.Buffer
+		err := comp.Render(ctx, &buf)
+		if err == nil {
+			equals(t, "EXPENSIVE", buf.String())
+		}
+		followerDone <- err
+	}()
+
+	// Give the follower time to actually start waiting on the leader's call
+	// before cancelling it, so this exercises promotion and not just a fresh
+	// leader that happens to start after the first one already failed.
+	time.Sleep(10 * time.Millisecond)
+	cancelLeader()
+
+	// Give the promoted follower time to start its own render before letting
+	// it complete.
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	assert(t, <-leaderDone != nil, "expected the leader's render to fail once its ctx was cancelled")
+	equals(t, nil, <-followerDone)
+	assert(t, atomic.LoadInt32(&calls) == 2, "expected the leader's render and the promoted follower's retry, got %d calls", atomic.LoadInt32(&calls))
+}
+
+func TestCoalesceDisabled(t *testing.T) {
+	var calls int32
+	child := slowChild{calls: &calls, delay: 20 * time.Millisecond, body: "EXPENSIVE"}
+
+	builder := New(WithTTL(time.Minute), WithCoalesce(false))
+	comp := builder("expensive")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx := templ.WithChildren(context.Background(), child)
+			comp.Render(ctx, io.Discard)
+		}()
+	}
+	wg.Wait()
+
+	assert(t, atomic.LoadInt32(&calls) > 1, "expected multiple renders with coalescing disabled")
+}
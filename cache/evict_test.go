@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/a-h/templ"
+)
+
+func TestOnEvict(t *testing.T) {
+	var mu sync.Mutex
+	var reasons []EvictReason
+
+	var values []string
+
+	builder := New(WithMaxMemory(100), WithOnEvict(func(key string, value []byte, reason EvictReason) {
+		mu.Lock()
+		defer mu.Unlock()
+		reasons = append(reasons, reason)
+		values = append(values, string(value))
+	}))
+	ctl := builder("")
+
+	store := ctl.store.(*lru)
+	store.Set("A", []byte("AAA"), time.Minute)
+	store.Set("B", []byte("BBB"), time.Minute)
+	store.Set("C", []byte("CCC"), time.Minute)
+	store.Set("D", []byte("DDD"), time.Minute) // should evict the oldest entry (A)
+
+	store.Delete("B")
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert(t, len(reasons) >= 2, "expected at least an LRU eviction and a manual delete, got %v", reasons)
+	assert(t, reasons[len(reasons)-1] == EvictManual, "expected the last eviction to be manual, got %v", reasons[len(reasons)-1])
+	equals(t, "AAA", values[0])
+
+	stats := ctl.Stats()
+	equals(t, 1, stats.Evictions)
+}
+
+func TestKeyLabels(t *testing.T) {
+	builder := New(WithKeyLabels(true))
+	comp := builder("hot")
+
+	child := countingChild{calls: new(int32), body: "value"}
+	ctx := templ.WithChildren(context.Background(), child)
+
+	var buf bytes.Buffer
+	equals(t, nil, comp.Render(ctx, &buf)) // miss, records a non-hit
+
+	buf.Reset()
+	ctx = templ.WithChildren(context.Background(), child)
+	equals(t, nil, comp.Render(ctx, &buf)) // hit, records a hit
+
+	v, ok := comp.keyStats.Load("hot")
+	assert(t, ok, "expected key stats to be recorded for %q", "hot")
+
+	kc := v.(*keyCounter)
+	equals(t, int64(2), kc.reads)
+	equals(t, int64(1), kc.hits)
+}
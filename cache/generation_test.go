@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResetIsGenerational(t *testing.T) {
+	l := newLRU(1024)
+
+	l.Set("a", []byte("A"), time.Minute)
+	l.Set("b", []byte("B"), time.Minute)
+	equals(t, 2, l.Stats().Items)
+
+	l.Reset()
+
+	// Reset doesn't unlink existing entries; it only bumps the generation and
+	// zeroes the counters, so the list still holds the old, now-ghost entries.
+	equals(t, 2, l.list.Len())
+	equals(t, 0, l.Stats().Items)
+	equals(t, 0, l.Stats().UsedMemory)
+
+	_, ok := l.Get("a")
+	equals(t, false, ok)
+
+	// Get unlinked the ghost it touched.
+	equals(t, 1, l.list.Len())
+}
+
+func TestSetAfterResetReplacesGhostInPlace(t *testing.T) {
+	l := newLRU(1024)
+
+	l.Set("a", []byte("A"), time.Minute)
+	l.Reset()
+	l.Set("a", []byte("A2"), time.Minute)
+
+	value, ok := l.Get("a")
+	equals(t, true, ok)
+	equals(t, "A2", string(value))
+	equals(t, 1, l.Stats().Items)
+}
+
+func TestDeleteAfterResetIgnoresGhost(t *testing.T) {
+	l := newLRU(1024)
+
+	l.Set("a", []byte("A"), time.Minute)
+	l.Reset()
+
+	// Deleting a key whose only entry is a ghost from a prior generation must
+	// not corrupt the post-reset counters.
+	l.Delete("a")
+	equals(t, 0, l.Stats().Items)
+	equals(t, 0, l.Stats().UsedMemory)
+
+	l.Set("b", []byte("B"), time.Minute)
+	equals(t, 1, l.Stats().Items)
+}
+
+func TestJanitorReclaimsGhostsOnExpirySweep(t *testing.T) {
+	l := newLRU(1024)
+
+	l.Set("a", []byte("A"), time.Minute)
+	l.Reset()
+
+	var pending []evictNotice
+	l.earliestExpiration = time.Now()
+	l._evictExpired(&pending)
+
+	equals(t, 0, l.list.Len())
+	equals(t, 0, len(pending))
+}
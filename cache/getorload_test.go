@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoadCoalescesStampede(t *testing.T) {
+	var calls int32
+	builder := New(WithTTL(time.Minute))
+	comp := builder("")
+
+	loader := func() ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(20 * time.Millisecond)
+		return []byte("LOADED"), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := comp.GetOrLoad("hot", time.Minute, loader)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			equals(t, "LOADED", string(value))
+		}()
+	}
+	wg.Wait()
+
+	equals(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestGetOrLoadCachesResult(t *testing.T) {
+	var calls int32
+	builder := New(WithTTL(time.Minute))
+	comp := builder("")
+
+	loader := func() ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		return []byte("LOADED"), nil
+	}
+
+	value, err := comp.GetOrLoad("key", time.Minute, loader)
+	equals(t, nil, err)
+	equals(t, "LOADED", string(value))
+
+	value, err = comp.GetOrLoad("key", time.Minute, loader)
+	equals(t, nil, err)
+	equals(t, "LOADED", string(value))
+
+	equals(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestGetOrLoadPropagatesError(t *testing.T) {
+	builder := New(WithTTL(time.Minute))
+	comp := builder("")
+
+	wantErr := errors.New("load failed")
+	_, err := comp.GetOrLoad("key", time.Minute, func() ([]byte, error) {
+		return nil, wantErr
+	})
+	equals(t, wantErr, err)
+
+	// A failed load isn't cached, so the next call tries again.
+	value, err := comp.GetOrLoad("key", time.Minute, func() ([]byte, error) {
+		return []byte("OK"), nil
+	})
+	equals(t, nil, err)
+	equals(t, "OK", string(value))
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// lruHandle wraps an *lru with a background janitor goroutine that proactively
+// reclaims expired entries on an interval, instead of only lazily on read or
+// under memory pressure (see [WithJanitor]).
+//
+// The janitor goroutine is started with a reference to the raw *lru and its
+// stop channel only, never to the handle itself, so the handle can still be
+// garbage collected while the goroutine runs. A finalizer on the handle closes
+// the stop channel, so a caller that forgets to call Close doesn't leak the
+// goroutine forever (see https://github.com/hashicorp/golang-lru/pull/161,
+// which this mirrors).
+type lruHandle struct {
+	*lru
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// wrapWithJanitor starts a janitor goroutine for l, running _evictExpired every
+// interval, and returns a handle that stops it on Close or on garbage collection.
+func wrapWithJanitor(l *lru, interval time.Duration) *lruHandle {
+	stop := make(chan struct{})
+	h := &lruHandle{lru: l, stop: stop}
+
+	go janitorLoop(l, interval, stop)
+
+	runtime.SetFinalizer(h, func(h *lruHandle) {
+		h.Close()
+	})
+
+	return h
+}
+
+// janitorLoop runs until stop is closed. It intentionally closes over only l
+// and stop, not the *lruHandle that owns them, so the handle can still become
+// unreachable (and be finalized) while this goroutine is running.
+func janitorLoop(l *lru, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.lock.Lock()
+			var pending []evictNotice
+			l._evictExpired(&pending)
+			l.lock.Unlock()
+			l.notify(pending)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Close stops the janitor goroutine. It is safe to call more than once, and
+// safe to omit: if the handle is dropped without calling Close, its finalizer
+// stops the janitor once the handle is garbage collected.
+func (h *lruHandle) Close() {
+	h.closeOnce.Do(func() {
+		close(h.stop)
+	})
+}
+
+// asLRU unwraps store to the *lru underneath, looking through an *lruHandle if
+// necessary, for options (such as [WithOnEvict]) that only make sense against
+// the in-process LRU implementation.
+func asLRU(store Store) (*lru, bool) {
+	switch s := store.(type) {
+	case *lru:
+		return s, true
+	case *lruHandle:
+		return s.lru, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestJanitorReclaimsExpired(t *testing.T) {
+	builder := New(WithJanitor(10 * time.Millisecond))
+	comp := builder("")
+
+	comp.store.(*lruHandle).Set("a", []byte("A"), 5*time.Millisecond)
+
+	equals(t, 1, comp.Stats().Items)
+
+	time.Sleep(50 * time.Millisecond)
+
+	// Reclaimed by the janitor, not by a Get call (we never made one).
+	equals(t, 0, comp.Stats().Items)
+
+	comp.Close()
+}
+
+func TestWithJanitorIgnoredAfterWithStore(t *testing.T) {
+	fake := newFakeStore()
+	builder := New(WithStore(fake), WithJanitor(10*time.Millisecond))
+	comp := builder("")
+
+	equals(t, fake, comp.store)
+}
+
+func TestJanitorFinalizerStopsGoroutine(t *testing.T) {
+	l := newLRU(1024)
+	h := wrapWithJanitor(l, 5*time.Millisecond)
+	stop := h.stop
+
+	h = nil //lint:ignore SA4006 dropping the only reference is the point of the test
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		runtime.GC()
+
+		select {
+		case <-stop:
+			return // closed: the finalizer ran and stopped the janitor
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	t.Fatal("finalizer did not stop the janitor goroutine in time")
+}
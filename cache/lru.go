@@ -2,6 +2,8 @@ package cache
 
 import (
 	"container/list"
+	"context"
+	"errors"
 	"sync"
 	"time"
 )
@@ -12,33 +14,137 @@ import (
 // key will be deleted when it is read, or if _evictExpired is called
 // due to the memory limit being reached.
 //
+// Reset uses a generation counter rather than reallocating cache and list: it
+// just increments generation and zeroes the size counters, leaving every
+// existing entry in place but stale. An entry whose generation doesn't match
+// c.generation is a "ghost" from before the last Reset; it's unlinked the next
+// time it's encountered by Get, insert, or _evictExpired, without affecting
+// mem/count, since its memory was already accounted for as freed at Reset.
+// This turns Reset from O(entries) into O(1), at the cost of not reclaiming
+// ghost entries' memory until something happens to touch them.
+//
 // All cache operations are under mutex protection.
 type lru struct {
 	lock               sync.RWMutex
 	maxMem             int
 	mem                int
+	count              int // number of entries in the current generation; see Stats.Items
+	generation         int64
 	cache              map[string]*list.Element
 	list               *list.List
 	earliestExpiration time.Time
-	disabled           bool
 
 	// stats
-	reads int
-	hits  int
+	reads       int
+	hits        int
+	staleHits   int
+	tombstones  int
+	evictions   int // entries removed under memory pressure, reason EvictLRU
+	expirations int // entries removed for being past their TTL, reason EvictExpired
+
+	// onEvict, if set, is called for every entry removed from the cache (see
+	// [WithOnEvict]). It is always invoked outside of c.lock, so it may safely
+	// call back into the cache.
+	onEvict func(key string, value []byte, reason EvictReason)
+
+	// loadFlight coalesces concurrent GetOrLoad calls for the same key.
+	loadFlight *flightGroup
 }
 
+// EvictReason identifies why an entry was removed from the cache, as reported to
+// an OnEvict callback (see [WithOnEvict]).
+type EvictReason int
+
+const (
+	// EvictLRU means the entry was evicted to make room under memory pressure.
+	EvictLRU EvictReason = iota
+	// EvictExpired means the entry was removed because its TTL (or stale-while-
+	// revalidate window) had passed.
+	EvictExpired
+	// EvictManual means the entry was removed by an explicit Remove call.
+	EvictManual
+	// EvictReplaced means the entry was overwritten by a new value stored under
+	// the same key.
+	EvictReplaced
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictLRU:
+		return "lru"
+	case EvictExpired:
+		return "expired"
+	case EvictManual:
+		return "manual"
+	case EvictReplaced:
+		return "replaced"
+	default:
+		return "unknown"
+	}
+}
+
+// evictNotice records an eviction for an [lru.onEvict] callback to fire once the
+// lock protecting the cache has been released.
+type evictNotice struct {
+	key    string
+	value  []byte
+	reason EvictReason
+}
+
+// entryKind distinguishes a normal cached value from a negative-caching
+// tombstone recorded by [lru.SetError] or [lru.SetEmpty] (see
+// [cache.WithNegativeTTL]).
+type entryKind int
+
+const (
+	kindValue entryKind = iota
+	kindTombstoneError
+	kindTombstoneEmpty
+)
+
+// tombstoneSize is the fixed accounting size of a tombstone entry, regardless of
+// the length of its key or recorded error message, so that negative caching
+// can't be used to blow up memory usage the way caching arbitrary errors might.
+const tombstoneSize = 64
+
 type entry struct {
 	key        string
 	value      []byte
 	expiration time.Time
+
+	// staleUntil is the deadline up to which an expired entry may still be
+	// served via GetStale. It is the zero Time for entries stored without
+	// stale-while-revalidate ([lru.SetStale]).
+	staleUntil time.Time
+
+	// kind and errMsg describe a tombstone entry; kind is kindValue and errMsg is
+	// empty for an ordinarily cached value.
+	kind   entryKind
+	errMsg string
+
+	// generation is the lru.generation at the time this entry was inserted; see
+	// the lru doc comment.
+	generation int64
 }
 
 // size calculates the total storage for item, including 24 bytes for
-// the expiration time.Time.
+// the expiration time.Time. Tombstones always report tombstoneSize.
 func (e *entry) size() int {
+	if e.kind != kindValue {
+		return tombstoneSize
+	}
 	return len(e.key) + len(e.value) + 24
 }
 
+// deadline is the time after which e must be evicted entirely: its
+// stale-while-revalidate deadline if set, otherwise its expiration.
+func (e *entry) deadline() time.Time {
+	if e.staleUntil.IsZero() {
+		return e.expiration
+	}
+	return e.staleUntil
+}
+
 func newLRU(maxMem int) *lru {
 	return &lru{
 		maxMem:             maxMem,
@@ -46,85 +152,292 @@ func newLRU(maxMem int) *lru {
 		cache:              make(map[string]*list.Element),
 		list:               list.New(),
 		earliestExpiration: time.Now().Add(24 * time.Hour),
+		loadFlight:         newFlightGroup(),
 	}
 }
 
-func (c *lru) reset() {
+// Reset implements [Store]. Existing entries aren't actually unlinked here; see
+// the lru doc comment.
+func (c *lru) Reset() {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
+	c.generation++
 	c.mem = 0
-	c.list = list.New()
-	c.cache = make(map[string]*list.Element)
+	c.count = 0
+	c.reads, c.hits, c.staleHits = 0, 0, 0
+	c.tombstones, c.evictions, c.expirations = 0, 0, 0
 }
 
-func (c *lru) get(key string) ([]byte, bool) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
+// Stats implements [Store].
+func (c *lru) Stats() Stats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
 
-	if c.disabled {
-		return nil, false
+	return Stats{
+		MaxMemory:   c.maxMem,
+		UsedMemory:  c.mem,
+		Items:       c.count,
+		Reads:       c.reads,
+		Hits:        c.hits,
+		StaleHits:   c.staleHits,
+		Tombstones:  c.tombstones,
+		Evictions:   c.evictions,
+		Expirations: c.expirations,
 	}
+}
+
+// Get implements [Store]. An entry within its stale-while-revalidate window (see
+// [lru.SetStale]) is not considered a hit here; use GetStale to observe it.
+func (c *lru) Get(key string) ([]byte, bool) {
+	c.lock.Lock()
 
 	c.reads++
 
+	var pending []evictNotice
+	var value []byte
+	var hit bool
+
 	if elem, ok := c.cache[key]; ok {
 		e := elem.Value.(*entry)
-		if time.Now().Before(e.expiration) {
-			c.hits++
-			c.list.MoveToFront(elem)
-			return e.value, true
+		if e.generation != c.generation {
+			c._unlinkGhost(key)
+		} else {
+			now := time.Now()
+			if now.Before(e.expiration) {
+				c.hits++
+				c.list.MoveToFront(elem)
+				value, hit = e.value, true
+			} else if now.After(e.deadline()) {
+				c._deleteKey(e.key, EvictExpired, &pending)
+			}
 		}
-		c._deleteKey(e.key)
 	}
 
-	return nil, false
+	c.lock.Unlock()
+	c.notify(pending)
+
+	return value, hit
 }
 
-func (c *lru) put(key string, value []byte, ttl time.Duration) {
+// GetStale implements the stale-while-revalidate lookup used when
+// [cache.WithStaleWhileRevalidate] is configured. found is true if key has an
+// entry at all (whether fresh or stale); stale is true if that entry is past its
+// TTL but still within its stale window.
+func (c *lru) GetStale(key string) (value []byte, stale bool, found bool) {
 	c.lock.Lock()
-	defer c.lock.Unlock()
 
-	if c.disabled {
-		return
+	c.reads++
+
+	var pending []evictNotice
+
+	elem, ok := c.cache[key]
+	if !ok {
+		c.lock.Unlock()
+		return nil, false, false
+	}
+
+	e := elem.Value.(*entry)
+	if e.generation != c.generation {
+		c._unlinkGhost(key)
+		c.lock.Unlock()
+		return nil, false, false
+	}
+
+	now := time.Now()
+
+	switch {
+	case now.Before(e.expiration):
+		c.hits++
+		c.list.MoveToFront(elem)
+		value, stale, found = e.value, false, true
+	case !e.staleUntil.IsZero() && now.Before(e.staleUntil):
+		c.hits++
+		c.staleHits++
+		c.list.MoveToFront(elem)
+		value, stale, found = e.value, true, true
+	default:
+		c._deleteKey(e.key, EvictExpired, &pending)
+	}
+
+	c.lock.Unlock()
+	c.notify(pending)
+
+	return value, stale, found
+}
+
+// GetOrLoad returns the cached value for key if present, otherwise calls loader
+// and caches its result under key for ttl before returning it. Concurrent calls
+// for the same missing key are coalesced: loader runs at most once, and every
+// caller waiting on that key receives its result, preventing a stampede of
+// identical, possibly expensive, loads.
+func (c *lru) GetOrLoad(key string, ttl time.Duration, loader func() ([]byte, error)) ([]byte, error) {
+	if value, ok := c.Get(key); ok {
+		return value, nil
 	}
 
+	return c.loadFlight.do(context.Background(), key, func(context.Context) ([]byte, error) {
+		if value, ok := c.Get(key); ok {
+			return value, nil
+		}
+
+		value, err := loader()
+		if err != nil {
+			return nil, err
+		}
+
+		c.Set(key, value, ttl)
+
+		return value, nil
+	})
+}
+
+// Set implements [Store].
+func (c *lru) Set(key string, value []byte, ttl time.Duration) {
+	c.insert(key, value, time.Now().Add(ttl), time.Time{}, kindValue, "")
+}
+
+// SetStale stores value under key, fresh for ttl and then servable-but-stale
+// (via GetStale) for an additional staleWindow, as used by
+// [cache.WithStaleWhileRevalidate].
+func (c *lru) SetStale(key string, value []byte, ttl, staleWindow time.Duration) {
 	expiration := time.Now().Add(ttl)
+	c.insert(key, value, expiration, expiration.Add(staleWindow), kindValue, "")
+}
+
+// SetError records a tombstone for key: for the next ttl, GetTombstone reports
+// err instead of a render being required, as used by [cache.WithNegativeTTL].
+func (c *lru) SetError(key string, err error, ttl time.Duration) {
+	c.insert(key, nil, time.Now().Add(ttl), time.Time{}, kindTombstoneError, err.Error())
+}
+
+// SetEmpty records a tombstone for key: for the next ttl, GetTombstone reports an
+// empty body instead of a render being required, as used by
+// [cache.WithNegativeTTL].
+func (c *lru) SetEmpty(key string, ttl time.Duration) {
+	c.insert(key, nil, time.Now().Add(ttl), time.Time{}, kindTombstoneEmpty, "")
+}
+
+// GetTombstone reports whether key currently holds a negative-caching tombstone
+// (see [cache.WithNegativeTTL]). If isTombstone is false, the caller should fall
+// back to Get/GetStale, which may still find an ordinary cached value.
+func (c *lru) GetTombstone(key string) (err error, isTombstone bool) {
+	c.lock.Lock()
+
+	var pending []evictNotice
+
+	elem, ok := c.cache[key]
+	if !ok {
+		c.lock.Unlock()
+		return nil, false
+	}
+
+	e := elem.Value.(*entry)
+	if e.generation != c.generation {
+		c._unlinkGhost(key)
+		c.lock.Unlock()
+		return nil, false
+	}
+
+	if e.kind == kindValue {
+		c.lock.Unlock()
+		return nil, false
+	}
+
+	c.reads++
+
+	if time.Now().After(e.expiration) {
+		c._deleteKey(e.key, EvictExpired, &pending)
+		c.lock.Unlock()
+		c.notify(pending)
+		return nil, false
+	}
+
+	c.hits++
+	c.list.MoveToFront(elem)
+	if e.kind == kindTombstoneError {
+		err = errors.New(e.errMsg)
+	}
+
+	c.lock.Unlock()
+	c.notify(pending)
+
+	return err, true
+}
+
+func (c *lru) insert(key string, value []byte, expiration, staleUntil time.Time, kind entryKind, errMsg string) {
+	c.lock.Lock()
+
+	var pending []evictNotice
 
 	// Make sure the key is gone. Updating is possible but complicates size tracking.
-	c._deleteKey(key)
+	if elem, existed := c.cache[key]; existed {
+		if elem.Value.(*entry).generation == c.generation {
+			c._deleteKey(key, EvictReplaced, &pending)
+		} else {
+			c._unlinkGhost(key)
+		}
+	}
 
-	newEntry := &entry{key: key, value: value, expiration: expiration}
+	newEntry := &entry{key: key, value: value, expiration: expiration, staleUntil: staleUntil, kind: kind, errMsg: errMsg, generation: c.generation}
 	elem := c.list.PushFront(newEntry)
 	c.cache[key] = elem
 	c.mem += newEntry.size()
+	c.count++
+	if kind != kindValue {
+		c.tombstones++
+	}
 
-	if expiration.Before(c.earliestExpiration) {
-		c.earliestExpiration = expiration
+	if deadline := newEntry.deadline(); deadline.Before(c.earliestExpiration) {
+		c.earliestExpiration = deadline
 	}
 
 	// Bring cache size within max size
 	if c.mem > c.maxMem {
-		c._evictExpired()
+		c._evictExpired(&pending)
 
 		for c.mem > c.maxMem && c.list.Len() > 1 {
 			oldest := c.list.Back()
-			if oldest != nil {
-				c._deleteKey(oldest.Value.(*entry).key)
+			if oldest == nil {
+				break
+			}
+
+			e := oldest.Value.(*entry)
+			if e.generation != c.generation {
+				c._unlinkGhost(e.key)
+				continue
 			}
+
+			c._deleteKey(e.key, EvictLRU, &pending)
 		}
 	}
+
+	c.lock.Unlock()
+	c.notify(pending)
 }
 
-func (c *lru) deleteKey(key string) {
+// Delete implements [Store].
+func (c *lru) Delete(key string) {
 	c.lock.Lock()
-	defer c.lock.Unlock()
 
-	c._deleteKey(key)
+	var pending []evictNotice
+
+	if elem, ok := c.cache[key]; ok {
+		if elem.Value.(*entry).generation == c.generation {
+			c._deleteKey(key, EvictManual, &pending)
+		} else {
+			c._unlinkGhost(key)
+		}
+	}
+
+	c.lock.Unlock()
+	c.notify(pending)
 }
 
-// _deleteKey should only be called with the lock held.
-func (c *lru) _deleteKey(key string) {
+// _deleteKey should only be called with the lock held, for a key known to be
+// in the current generation. If c.onEvict is set, a notice is appended to
+// *pending so the callback can be fired once the lock is released.
+func (c *lru) _deleteKey(key string, reason EvictReason, pending *[]evictNotice) {
 	elem := c.cache[key]
 	if elem == nil {
 		return
@@ -133,11 +446,40 @@ func (c *lru) _deleteKey(key string) {
 	c.list.Remove(elem)
 	e := elem.Value.(*entry)
 	c.mem -= e.size()
+	c.count--
 	delete(c.cache, e.key)
+	if e.kind != kindValue {
+		c.tombstones--
+	}
+
+	switch reason {
+	case EvictLRU:
+		c.evictions++
+	case EvictExpired:
+		c.expirations++
+	}
+
+	if c.onEvict != nil {
+		*pending = append(*pending, evictNotice{key: e.key, value: e.value, reason: reason})
+	}
+}
+
+// _unlinkGhost should only be called with the lock held, for a key whose entry
+// belongs to a prior generation (see the lru doc comment). Unlike _deleteKey,
+// it doesn't touch mem/count or fire onEvict: a ghost's memory was already
+// accounted for as freed by the Reset that orphaned it.
+func (c *lru) _unlinkGhost(key string) {
+	elem, ok := c.cache[key]
+	if !ok {
+		return
+	}
+
+	c.list.Remove(elem)
+	delete(c.cache, key)
 }
 
 // _evictExpired should only be called with the lock held.
-func (c *lru) _evictExpired() {
+func (c *lru) _evictExpired(pending *[]evictNotice) {
 	now := time.Now()
 	if now.Before(c.earliestExpiration) {
 		return
@@ -150,10 +492,28 @@ func (c *lru) _evictExpired() {
 		next = elem.Prev()
 
 		e := elem.Value.(*entry)
-		if now.After(e.expiration) {
-			c._deleteKey(e.key)
-		} else if e.expiration.Before(c.earliestExpiration) {
-			c.earliestExpiration = e.expiration
+		if e.generation != c.generation {
+			c._unlinkGhost(e.key)
+			continue
 		}
+
+		deadline := e.deadline()
+		if now.After(deadline) {
+			c._deleteKey(e.key, EvictExpired, pending)
+		} else if deadline.Before(c.earliestExpiration) {
+			c.earliestExpiration = deadline
+		}
+	}
+}
+
+// notify fires c.onEvict for each pending eviction notice. It must be called
+// without c.lock held.
+func (c *lru) notify(pending []evictNotice) {
+	if c.onEvict == nil {
+		return
+	}
+
+	for _, n := range pending {
+		c.onEvict(n.key, n.value, n.reason)
 	}
 }
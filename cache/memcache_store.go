@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// MemcacheStore is a [Store] backed by memcached, allowing cached component output
+// to be shared across multiple processes running behind a load balancer. TTL
+// expiration is enforced natively by memcached.
+type MemcacheStore struct {
+	client *memcache.Client
+	prefix string
+
+	reads int64
+	hits  int64
+}
+
+// NewMemcacheStore creates a [Store] backed by the given memcached client. Keys are
+// stored under prefix, so that a single memcached instance can be shared safely
+// with other uses.
+func NewMemcacheStore(client *memcache.Client, prefix string) *MemcacheStore {
+	return &MemcacheStore{client: client, prefix: prefix}
+}
+
+// Get implements [Store].
+func (s *MemcacheStore) Get(key string) ([]byte, bool) {
+	atomic.AddInt64(&s.reads, 1)
+
+	item, err := s.client.Get(s.prefix + key)
+	if err != nil {
+		return nil, false
+	}
+
+	atomic.AddInt64(&s.hits, 1)
+	return item.Value, true
+}
+
+// Set implements [Store].
+func (s *MemcacheStore) Set(key string, value []byte, ttl time.Duration) {
+	s.client.Set(&memcache.Item{
+		Key:        s.prefix + key,
+		Value:      value,
+		Expiration: memcacheExpiration(ttl),
+	})
+}
+
+// memcacheExpirationCutoff is the boundary, per the memcached protocol, past
+// which an Item.Expiration is interpreted as an absolute Unix timestamp
+// instead of a number of seconds from now.
+const memcacheExpirationCutoff = 30 * 24 * time.Hour
+
+// memcacheExpiration converts ttl to the value memcached expects for
+// Item.Expiration: 0 means "never expire", so a sub-second ttl is rounded up
+// to 1 second rather than truncating to 0, and a ttl past
+// [memcacheExpirationCutoff] is converted to an absolute Unix timestamp rather
+// than being reinterpreted by memcached as one.
+func memcacheExpiration(ttl time.Duration) int32 {
+	if ttl <= 0 {
+		return 0
+	}
+
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+
+	if ttl > memcacheExpirationCutoff {
+		return int32(time.Now().Add(ttl).Unix())
+	}
+
+	return int32(ttl.Seconds())
+}
+
+// Delete implements [Store].
+func (s *MemcacheStore) Delete(key string) {
+	s.client.Delete(s.prefix + key)
+}
+
+// Reset implements [Store], but only partially: memcached has no notion of
+// "all keys under this prefix" and no SCAN-equivalent to enumerate them, so
+// unlike [RedisStore.Reset] it can't wipe the underlying entries, only the
+// local read/hit statistics. A [Component.Reset] backed by MemcacheStore (or
+// one propagated from a peer via [WithBus]) therefore leaves every cached
+// entry in place to expire via its own TTL; see [Store.Reset].
+func (s *MemcacheStore) Reset() {
+	atomic.StoreInt64(&s.reads, 0)
+	atomic.StoreInt64(&s.hits, 0)
+}
+
+// Stats implements [Store]. MaxMemory, UsedMemory, and Items are reported as -1,
+// since memcached doesn't expose the memory used by an arbitrary key prefix.
+func (s *MemcacheStore) Stats() Stats {
+	return Stats{
+		MaxMemory:   -1,
+		UsedMemory:  -1,
+		Items:       -1,
+		Reads:       int(atomic.LoadInt64(&s.reads)),
+		Hits:        int(atomic.LoadInt64(&s.hits)),
+		Evictions:   -1,
+		Expirations: -1,
+	}
+}
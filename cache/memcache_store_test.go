@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemcacheExpiration(t *testing.T) {
+	equals(t, int32(0), memcacheExpiration(0))
+	equals(t, int32(0), memcacheExpiration(-time.Second))
+
+	// Sub-second ttls round up to 1s instead of truncating to 0, which
+	// memcached treats as "never expire".
+	equals(t, int32(1), memcacheExpiration(500*time.Millisecond))
+
+	equals(t, int32(30), memcacheExpiration(30*time.Second))
+	equals(t, int32(60), memcacheExpiration(time.Minute))
+
+	// A ttl right at the 30-day cutoff is still a relative number of seconds.
+	equals(t, int32(memcacheExpirationCutoff.Seconds()), memcacheExpiration(memcacheExpirationCutoff))
+}
+
+func TestMemcacheExpirationPastCutoffIsAbsolute(t *testing.T) {
+	ttl := memcacheExpirationCutoff + time.Hour
+
+	before := time.Now().Add(ttl).Unix()
+	got := memcacheExpiration(ttl)
+	after := time.Now().Add(ttl).Unix()
+
+	assert(t, int64(got) >= before && int64(got) <= after, "expected an absolute Unix timestamp around %d, got %d", before, got)
+}
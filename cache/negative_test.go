@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/a-h/templ"
+)
+
+type failingChild struct {
+	calls *int32
+	err   error
+	body  string
+}
+
+func (c failingChild) Render(ctx context.Context, w io.Writer) error {
+	atomic.AddInt32(c.calls, 1)
+	if c.err != nil {
+		return c.err
+	}
+	_, err := io.WriteString(w, c.body)
+	return err
+}
+
+func TestNegativeTTLCachesError(t *testing.T) {
+	var calls int32
+	wantErr := errors.New("upstream unavailable")
+	child := failingChild{calls: &calls, err: wantErr}
+
+	builder := New(WithNegativeTTL(50 * time.Millisecond))
+	comp := builder("error-key")
+
+	render := func() error {
+		ctx := templ.WithChildren(context.Background(), child)
+		var buf bytes.Buffer
+		return comp.Render(ctx, &buf)
+	}
+
+	equals(t, wantErr, render())
+	equals(t, int32(1), atomic.LoadInt32(&calls))
+
+	// Replayed from the tombstone: no second render.
+	equals(t, wantErr, render())
+	equals(t, int32(1), atomic.LoadInt32(&calls))
+
+	assert(t, comp.Stats().Tombstones == 1, "expected one tombstone, got %d", comp.Stats().Tombstones)
+
+	time.Sleep(60 * time.Millisecond)
+	equals(t, wantErr, render())
+	equals(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestNegativeTTLCachesEmpty(t *testing.T) {
+	var calls int32
+	child := failingChild{calls: &calls, body: ""}
+
+	builder := New(WithNegativeTTL(time.Minute))
+	comp := builder("empty-key")
+
+	render := func() string {
+		ctx := templ.WithChildren(context.Background(), child)
+		var buf bytes.Buffer
+		if err := comp.Render(ctx, &buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	equals(t, "", render())
+	equals(t, int32(1), atomic.LoadInt32(&calls))
+
+	equals(t, "", render())
+	equals(t, int32(1), atomic.LoadInt32(&calls))
+
+	assert(t, comp.Stats().Tombstones == 1, "expected one tombstone, got %d", comp.Stats().Tombstones)
+}
+
+func TestNegativeTTLDisabledByDefault(t *testing.T) {
+	var calls int32
+	wantErr := errors.New("boom")
+	child := failingChild{calls: &calls, err: wantErr}
+
+	builder := New()
+	comp := builder("no-negative-ttl")
+
+	ctx := templ.WithChildren(context.Background(), child)
+	var buf bytes.Buffer
+	equals(t, wantErr, comp.Render(ctx, &buf))
+
+	ctx = templ.WithChildren(context.Background(), child)
+	buf.Reset()
+	equals(t, wantErr, comp.Render(ctx, &buf))
+
+	equals(t, int32(2), atomic.LoadInt32(&calls))
+	equals(t, 0, comp.Stats().Tombstones)
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// descOf returns m's description, used below to tell which metric m is. It
+// panics if m is nil, which would indicate a nil metric slipped onto the
+// channel.
+func descOf(m prometheus.Metric) string {
+	return m.Desc().String()
+}
+
+func TestPrometheusCollectorSkipsUnsupportedCounters(t *testing.T) {
+	// fakeStore (see store_test.go) reports Evictions and Expirations as -1,
+	// like RedisStore and MemcacheStore, since none of them can track these.
+	builder := New(WithStore(newFakeStore()))
+	collector := NewPrometheusCollector(builder)
+
+	ch := make(chan prometheus.Metric, 16)
+	collector.Collect(ch)
+	close(ch)
+
+	for m := range ch {
+		desc := descOf(m)
+		assert(t, desc != evictionsDesc.String(), "did not expect an evictions metric for a store reporting -1, got %v", m)
+		assert(t, desc != expirationsDesc.String(), "did not expect an expirations metric for a store reporting -1, got %v", m)
+	}
+}
+
+func TestPrometheusCollectorEmitsCountersWhenSupported(t *testing.T) {
+	builder := New(WithMaxMemory(1024))
+	collector := NewPrometheusCollector(builder)
+
+	ch := make(chan prometheus.Metric, 16)
+	collector.Collect(ch)
+	close(ch)
+
+	var sawEvictions, sawExpirations bool
+	for m := range ch {
+		switch descOf(m) {
+		case evictionsDesc.String():
+			sawEvictions = true
+		case expirationsDesc.String():
+			sawExpirations = true
+		}
+	}
+
+	assert(t, sawEvictions, "expected an evictions metric for the in-process LRU")
+	assert(t, sawExpirations, "expected an expirations metric for the in-process LRU")
+}
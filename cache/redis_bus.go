@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisBus is a [Bus] backed by Redis Pub/Sub, letting multiple processes that
+// share a Redis instance propagate cache invalidation to each other.
+type RedisBus struct {
+	client  *redis.Client
+	channel string
+
+	mu  sync.Mutex
+	sub *redis.PubSub // set by Subscribe; guards the background goroutine it starts
+}
+
+// NewRedisBus creates a [Bus] that publishes and subscribes on the given Redis
+// Pub/Sub channel.
+func NewRedisBus(client *redis.Client, channel string) *RedisBus {
+	return &RedisBus{client: client, channel: channel}
+}
+
+// Publish implements [Bus].
+func (b *RedisBus) Publish(msg InvalidateMsg) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return b.client.Publish(context.Background(), b.channel, payload).Err()
+}
+
+// Subscribe implements [Bus]. It starts a background goroutine that runs until
+// the underlying Redis subscription is closed by Close.
+func (b *RedisBus) Subscribe(handler func(InvalidateMsg)) error {
+	sub := b.client.Subscribe(context.Background(), b.channel)
+
+	b.mu.Lock()
+	b.sub = sub
+	b.mu.Unlock()
+
+	go func() {
+		for redisMsg := range sub.Channel() {
+			var msg InvalidateMsg
+			if err := json.Unmarshal([]byte(redisMsg.Payload), &msg); err != nil {
+				continue
+			}
+			handler(msg)
+		}
+	}()
+
+	return nil
+}
+
+// Close implements the unexported closer interface (see [Component.Close]),
+// stopping the background goroutine started by Subscribe, if any. It's safe to
+// call even if Subscribe was never called.
+func (b *RedisBus) Close() {
+	b.mu.Lock()
+	sub := b.sub
+	b.sub = nil
+	b.mu.Unlock()
+
+	if sub != nil {
+		sub.Close()
+	}
+}
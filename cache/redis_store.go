@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore is a [Store] backed by Redis, allowing cached component output to be
+// shared across multiple processes running behind a load balancer. TTL expiration
+// is enforced natively by Redis, so eviction under memory pressure is left to the
+// Redis instance's own configuration rather than this package.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+
+	reads int64
+	hits  int64
+}
+
+// NewRedisStore creates a [Store] backed by the given Redis client. Keys are stored
+// under prefix, so that a single Redis instance can be shared safely with other uses.
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Get implements [Store].
+func (s *RedisStore) Get(key string) ([]byte, bool) {
+	atomic.AddInt64(&s.reads, 1)
+
+	value, err := s.client.Get(context.Background(), s.prefix+key).Bytes()
+	if err != nil {
+		return nil, false
+	}
+
+	atomic.AddInt64(&s.hits, 1)
+	return value, true
+}
+
+// Set implements [Store].
+func (s *RedisStore) Set(key string, value []byte, ttl time.Duration) {
+	s.client.Set(context.Background(), s.prefix+key, value, ttl)
+}
+
+// Delete implements [Store].
+func (s *RedisStore) Delete(key string) {
+	s.client.Del(context.Background(), s.prefix+key)
+}
+
+// Reset implements [Store]. It SCANs for every key under s.prefix and removes
+// them with UNLINK (an async delete, so it doesn't block the Redis instance
+// even for a large keyspace), in addition to resetting local statistics. This
+// is best-effort: SCAN only guarantees that a key present for the whole scan is
+// returned at least once, so a key written concurrently with Reset may or may
+// not be removed.
+func (s *RedisStore) Reset() {
+	atomic.StoreInt64(&s.reads, 0)
+	atomic.StoreInt64(&s.hits, 0)
+
+	ctx := context.Background()
+
+	var cursor uint64
+	for {
+		keys, next, err := s.client.Scan(ctx, cursor, s.prefix+"*", 0).Result()
+		if err != nil {
+			return
+		}
+
+		if len(keys) > 0 {
+			s.client.Unlink(ctx, keys...)
+		}
+
+		if next == 0 {
+			return
+		}
+		cursor = next
+	}
+}
+
+// Stats implements [Store]. MaxMemory, UsedMemory, and Items are reported as -1,
+// since Redis doesn't expose the memory used by an arbitrary key prefix.
+func (s *RedisStore) Stats() Stats {
+	return Stats{
+		MaxMemory:   -1,
+		UsedMemory:  -1,
+		Items:       -1,
+		Reads:       int(atomic.LoadInt64(&s.reads)),
+		Hits:        int(atomic.LoadInt64(&s.hits)),
+		Evictions:   -1,
+		Expirations: -1,
+	}
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"hash/fnv"
+	"time"
+)
+
+// defaultShards is the shard count [NewSharded] uses when none is given.
+const defaultShards = 256
+
+// shardedLRU fronts several independent [lru] shards, keyed by fnv32a(key) % N,
+// so that concurrent Get/Set calls for different keys don't all serialize on
+// the single mutex a plain [lru] uses (see [NewSharded]). It only implements
+// [Store]: stale-while-revalidate, negative caching, and GetOrLoad aren't
+// supported across shards, the same as the external [RedisStore] and
+// [MemcacheStore] adapters.
+type shardedLRU struct {
+	shards []*lru
+}
+
+// NewSharded returns a [Store] that spreads its entries across shards
+// independent in-process LRUs, each sized maxMem/shards, trading a little
+// memory-accounting precision (a hot key on one shard can't borrow space from
+// an idle one) for much less lock contention under concurrent access from many
+// goroutines. If shards is 0, it defaults to 256.
+func NewSharded(maxMem, shards int) *shardedLRU {
+	if shards == 0 {
+		shards = defaultShards
+	}
+
+	s := &shardedLRU{shards: make([]*lru, shards)}
+	for i := range s.shards {
+		s.shards[i] = newLRU(maxMem / shards)
+	}
+
+	return s
+}
+
+// shardFor returns the shard responsible for key.
+func (s *shardedLRU) shardFor(key string) *lru {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+
+	return s.shards[h.Sum32()%uint32(len(s.shards))]
+}
+
+// Get implements [Store].
+func (s *shardedLRU) Get(key string) ([]byte, bool) {
+	return s.shardFor(key).Get(key)
+}
+
+// Set implements [Store].
+func (s *shardedLRU) Set(key string, value []byte, ttl time.Duration) {
+	s.shardFor(key).Set(key, value, ttl)
+}
+
+// Delete implements [Store].
+func (s *shardedLRU) Delete(key string) {
+	s.shardFor(key).Delete(key)
+}
+
+// Reset implements [Store].
+func (s *shardedLRU) Reset() {
+	for _, shard := range s.shards {
+		shard.Reset()
+	}
+}
+
+// Stats implements [Store], aggregating every shard's statistics.
+func (s *shardedLRU) Stats() Stats {
+	var total Stats
+
+	for _, shard := range s.shards {
+		stats := shard.Stats()
+		total.MaxMemory += stats.MaxMemory
+		total.UsedMemory += stats.UsedMemory
+		total.Items += stats.Items
+		total.Reads += stats.Reads
+		total.Hits += stats.Hits
+		total.StaleHits += stats.StaleHits
+		total.Tombstones += stats.Tombstones
+		total.Evictions += stats.Evictions
+		total.Expirations += stats.Expirations
+	}
+
+	return total
+}
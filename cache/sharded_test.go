@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestShardedGetSetDelete(t *testing.T) {
+	s := NewSharded(1024*1024, 4)
+
+	_, ok := s.Get("a")
+	equals(t, false, ok)
+
+	s.Set("a", []byte("A"), time.Minute)
+	value, ok := s.Get("a")
+	equals(t, true, ok)
+	equals(t, "A", string(value))
+
+	s.Delete("a")
+	_, ok = s.Get("a")
+	equals(t, false, ok)
+}
+
+func TestShardedDistributesAcrossShards(t *testing.T) {
+	s := NewSharded(1024*1024, 8)
+
+	for i := 0; i < 100; i++ {
+		s.Set(fmt.Sprintf("key-%d", i), []byte("v"), time.Minute)
+	}
+
+	seen := make(map[*lru]bool)
+	for i := 0; i < 100; i++ {
+		seen[s.shardFor(fmt.Sprintf("key-%d", i))] = true
+	}
+	assert(t, len(seen) > 1, "expected keys to spread across more than one shard, got %d", len(seen))
+
+	var items int
+	for _, shard := range s.shards {
+		items += shard.Stats().Items
+	}
+	equals(t, 100, items)
+}
+
+func TestShardedMaxMemorySplitEvenly(t *testing.T) {
+	s := NewSharded(1000, 4)
+
+	equals(t, 1000, s.Stats().MaxMemory)
+	for _, shard := range s.shards {
+		equals(t, 250, shard.Stats().MaxMemory)
+	}
+}
+
+func TestShardedDefaultShardCount(t *testing.T) {
+	s := NewSharded(1024*1024, 0)
+	equals(t, defaultShards, len(s.shards))
+}
+
+func TestShardedStatsAggregation(t *testing.T) {
+	s := NewSharded(1024*1024, 4)
+
+	for i := 0; i < 20; i++ {
+		s.Set(strconv.Itoa(i), []byte("v"), time.Minute)
+	}
+	for i := 0; i < 20; i++ {
+		s.Get(strconv.Itoa(i))
+	}
+	s.Get("missing")
+
+	stats := s.Stats()
+	equals(t, 20, stats.Items)
+	equals(t, 21, stats.Reads)
+	equals(t, 20, stats.Hits)
+
+	s.Reset()
+	equals(t, 0, s.Stats().Items)
+}
+
+// BenchmarkLRUParallel exercises the single-mutex [lru] under concurrent,
+// uniformly-distributed keys: every goroutine serializes on the same lock.
+func BenchmarkLRUParallel(b *testing.B) {
+	c := newLRU(64 * 1024 * 1024)
+	for i := 0; i < 1000; i++ {
+		c.Set(strconv.Itoa(i), []byte("value"), time.Minute)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := strconv.Itoa(i % 1000)
+			c.Get(key)
+			i++
+		}
+	})
+}
+
+// BenchmarkShardedParallel exercises [shardedLRU] with the same workload as
+// BenchmarkLRUParallel, demonstrating that spreading keys across shards scales
+// with GOMAXPROCS instead of serializing on a single lock.
+func BenchmarkShardedParallel(b *testing.B) {
+	s := NewSharded(64*1024*1024, 256)
+	for i := 0; i < 1000; i++ {
+		s.Set(strconv.Itoa(i), []byte("value"), time.Minute)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := strconv.Itoa(i % 1000)
+			s.Get(key)
+			i++
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeStore is a minimal in-memory [Store] used to test that Component only
+// depends on the Store interface, not on the concrete *lru implementation.
+type fakeStore struct {
+	values map[string][]byte
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{values: make(map[string][]byte)}
+}
+
+func (s *fakeStore) Get(key string) ([]byte, bool) {
+	v, ok := s.values[key]
+	return v, ok
+}
+
+func (s *fakeStore) Set(key string, value []byte, ttl time.Duration) {
+	s.values[key] = value
+}
+
+func (s *fakeStore) Delete(key string) {
+	delete(s.values, key)
+}
+
+func (s *fakeStore) Reset() {
+	s.values = make(map[string][]byte)
+}
+
+func (s *fakeStore) Stats() Stats {
+	return Stats{MaxMemory: -1, UsedMemory: -1, Items: len(s.values), Reads: -1, Hits: -1, Evictions: -1, Expirations: -1}
+}
+
+func TestWithStore(t *testing.T) {
+	store := newFakeStore()
+	builder := New(WithStore(store))
+	ctl := builder("")
+
+	store.Set("greeting", []byte("hello"), defaultTTL)
+	equals(t, 1, ctl.Stats().Items)
+
+	ctl.Remove("greeting")
+	equals(t, 0, ctl.Stats().Items)
+
+	store.Set("a", []byte("A"), defaultTTL)
+	store.Set("b", []byte("B"), defaultTTL)
+	ctl.Reset()
+	equals(t, 0, ctl.Stats().Items)
+}
+
+// TestWithStoreWinsRegardlessOfOrder guards against WithMaxMemory/WithTwoQueue
+// silently discarding an explicit WithStore, whichever order they're passed in.
+func TestWithStoreWinsRegardlessOfOrder(t *testing.T) {
+	storeThenMaxMemory := New(WithStore(newFakeStore()), WithMaxMemory(1024))("")
+	_, ok := storeThenMaxMemory.store.(*fakeStore)
+	assert(t, ok, "expected WithStore followed by WithMaxMemory to keep the fake store, got %T", storeThenMaxMemory.store)
+
+	maxMemoryThenStore := New(WithMaxMemory(1024), WithStore(newFakeStore()))("")
+	_, ok = maxMemoryThenStore.store.(*fakeStore)
+	assert(t, ok, "expected WithMaxMemory followed by WithStore to install the fake store, got %T", maxMemoryThenStore.store)
+
+	storeThenTwoQueue := New(WithStore(newFakeStore()), WithTwoQueue(1024))("")
+	_, ok = storeThenTwoQueue.store.(*fakeStore)
+	assert(t, ok, "expected WithStore followed by WithTwoQueue to keep the fake store, got %T", storeThenTwoQueue.store)
+
+	twoQueueThenStore := New(WithTwoQueue(1024), WithStore(newFakeStore()))("")
+	_, ok = twoQueueThenStore.store.(*fakeStore)
+	assert(t, ok, "expected WithTwoQueue followed by WithStore to install the fake store, got %T", twoQueueThenStore.store)
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/a-h/templ"
+)
+
+type countingChild struct {
+	calls *int32
+	body  string
+}
+
+func (c countingChild) Render(ctx context.Context, w io.Writer) error {
+	atomic.AddInt32(c.calls, 1)
+	_, err := io.WriteString(w, c.body)
+	return err
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	var calls int32
+	child := countingChild{calls: &calls, body: "FRESH"}
+
+	builder := New(WithTTL(30*time.Millisecond), WithStaleWhileRevalidate(200*time.Millisecond))
+	comp := builder("swr-key")
+
+	render := func() string {
+		ctx := templ.WithChildren(context.Background(), child)
+		var buf bytes.Buffer
+		if err := comp.Render(ctx, &buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	equals(t, "FRESH", render())
+	equals(t, int32(1), atomic.LoadInt32(&calls))
+
+	// Still fresh: served from cache, no re-render.
+	equals(t, "FRESH", render())
+	equals(t, int32(1), atomic.LoadInt32(&calls))
+
+	// Past TTL but within the stale window: served immediately from cache,
+	// with a background re-render kicked off.
+	time.Sleep(50 * time.Millisecond)
+	equals(t, "FRESH", render())
+
+	// Wait for the background revalidation to complete.
+	time.Sleep(50 * time.Millisecond)
+	equals(t, int32(2), atomic.LoadInt32(&calls))
+
+	assert(t, comp.Stats().StaleHits >= 1, "expected at least one stale hit, got %d", comp.Stats().StaleHits)
+}
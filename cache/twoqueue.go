@@ -0,0 +1,267 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// twoQueueRecentRatio and twoQueueGhostRatio split a twoQueue's maxMem between
+// its recent and ghost lists, matching the defaults hashicorp/golang-lru uses
+// for its 2Q implementation.
+const (
+	twoQueueRecentRatio = 0.25
+	twoQueueGhostRatio  = 0.50
+
+	// twoQueueGhostEntryCost is the assumed bookkeeping cost of a single ghost
+	// entry, used to turn the byte budget implied by twoQueueGhostRatio into a
+	// count limit for the ghost list, which retains only keys, not values.
+	twoQueueGhostEntryCost = 64
+)
+
+// twoQueue implements a cache with the 2Q eviction policy, as an alternative to
+// the plain [lru] (see [WithTwoQueue]). It keeps three lists: a small "recent"
+// FIFO for entries seen only once, a larger "frequent" LRU for entries promoted
+// after a second hit, and a "ghost" FIFO of recently evicted keys (values
+// discarded, keys retained). A key found in the ghost list on Set skips recent
+// entirely and goes straight into frequent, since it's already proven itself
+// worth keeping once before. This avoids the classic LRU weakness where a single
+// scan through many keys, each touched once, evicts a working set of keys that
+// are actually accessed repeatedly.
+//
+// Like lru, expiration is tracked per item, but it is handled lazily on read
+// only: Set never removes expired entries, and eviction under memory pressure
+// follows list order whether or not an entry has expired. All operations are
+// under mutex protection.
+type twoQueue struct {
+	lock sync.RWMutex
+
+	maxMem    int
+	recentMax int // byte budget for the recent list; ~twoQueueRecentRatio of maxMem
+	ghostMax  int // entry count budget for the ghost list
+
+	recentMem   int
+	frequentMem int
+
+	recentCache   map[string]*list.Element
+	recentList    *list.List
+	frequentCache map[string]*list.Element
+	frequentList  *list.List
+	ghostCache    map[string]*list.Element // value is a *list.Element of ghostList, whose Value is the key
+	ghostList     *list.List
+
+	// stats
+	reads       int
+	hits        int
+	evictions   int
+	expirations int
+}
+
+func newTwoQueue(maxMem int) *twoQueue {
+	return &twoQueue{
+		maxMem:        maxMem,
+		recentMax:     int(float64(maxMem) * twoQueueRecentRatio),
+		ghostMax:      int(float64(maxMem) * twoQueueGhostRatio / twoQueueGhostEntryCost),
+		recentCache:   make(map[string]*list.Element),
+		recentList:    list.New(),
+		frequentCache: make(map[string]*list.Element),
+		frequentList:  list.New(),
+		ghostCache:    make(map[string]*list.Element),
+		ghostList:     list.New(),
+	}
+}
+
+// Get implements [Store].
+func (c *twoQueue) Get(key string) ([]byte, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.reads++
+	now := time.Now()
+
+	if elem, ok := c.frequentCache[key]; ok {
+		e := elem.Value.(*entry)
+		if now.After(e.expiration) {
+			c._deleteFrequent(key)
+			c.expirations++
+			return nil, false
+		}
+
+		c.hits++
+		c.frequentList.MoveToFront(elem)
+		return e.value, true
+	}
+
+	if elem, ok := c.recentCache[key]; ok {
+		e := elem.Value.(*entry)
+		if now.After(e.expiration) {
+			c._deleteRecent(key)
+			c.expirations++
+			return nil, false
+		}
+
+		c.hits++
+		// A second access promotes the entry out of recent into frequent.
+		c._deleteRecent(key)
+		c._insertFrequent(e)
+		return e.value, true
+	}
+
+	return nil, false
+}
+
+// Set implements [Store].
+func (c *twoQueue) Set(key string, value []byte, ttl time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	newEntry := &entry{key: key, value: value, expiration: time.Now().Add(ttl)}
+
+	if elem, ok := c.frequentCache[key]; ok {
+		c.frequentMem -= elem.Value.(*entry).size()
+		elem.Value = newEntry
+		c.frequentMem += newEntry.size()
+		c.frequentList.MoveToFront(elem)
+		c._evict()
+		return
+	}
+
+	if elem, ok := c.recentCache[key]; ok {
+		c.recentMem -= elem.Value.(*entry).size()
+		elem.Value = newEntry
+		c.recentMem += newEntry.size()
+		c.recentList.MoveToFront(elem)
+		c._evict()
+		return
+	}
+
+	if ghostElem, ok := c.ghostCache[key]; ok {
+		c.ghostList.Remove(ghostElem)
+		delete(c.ghostCache, key)
+		c._insertFrequent(newEntry)
+		c._evict()
+		return
+	}
+
+	c._insertRecent(newEntry)
+	c._evict()
+}
+
+// Delete implements [Store].
+func (c *twoQueue) Delete(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c._deleteRecent(key)
+	c._deleteFrequent(key)
+}
+
+// Reset implements [Store].
+func (c *twoQueue) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.recentMem, c.frequentMem = 0, 0
+	c.reads, c.hits, c.evictions, c.expirations = 0, 0, 0, 0
+	c.recentCache = make(map[string]*list.Element)
+	c.recentList = list.New()
+	c.frequentCache = make(map[string]*list.Element)
+	c.frequentList = list.New()
+	c.ghostCache = make(map[string]*list.Element)
+	c.ghostList = list.New()
+}
+
+// Stats implements [Store].
+func (c *twoQueue) Stats() Stats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return Stats{
+		MaxMemory:   c.maxMem,
+		UsedMemory:  c.recentMem + c.frequentMem,
+		Items:       c.recentList.Len() + c.frequentList.Len(),
+		Reads:       c.reads,
+		Hits:        c.hits,
+		Evictions:   c.evictions,
+		Expirations: c.expirations,
+	}
+}
+
+func (c *twoQueue) _insertRecent(e *entry) {
+	elem := c.recentList.PushFront(e)
+	c.recentCache[e.key] = elem
+	c.recentMem += e.size()
+}
+
+func (c *twoQueue) _insertFrequent(e *entry) {
+	elem := c.frequentList.PushFront(e)
+	c.frequentCache[e.key] = elem
+	c.frequentMem += e.size()
+}
+
+func (c *twoQueue) _deleteRecent(key string) {
+	elem, ok := c.recentCache[key]
+	if !ok {
+		return
+	}
+
+	c.recentList.Remove(elem)
+	delete(c.recentCache, key)
+	c.recentMem -= elem.Value.(*entry).size()
+}
+
+func (c *twoQueue) _deleteFrequent(key string) {
+	elem, ok := c.frequentCache[key]
+	if !ok {
+		return
+	}
+
+	c.frequentList.Remove(elem)
+	delete(c.frequentCache, key)
+	c.frequentMem -= elem.Value.(*entry).size()
+}
+
+// _ghostAdd records key, with its value discarded, as recently evicted from
+// recent, trimming the ghost list back to c.ghostMax if needed.
+func (c *twoQueue) _ghostAdd(key string) {
+	if _, ok := c.ghostCache[key]; ok {
+		return
+	}
+
+	elem := c.ghostList.PushFront(key)
+	c.ghostCache[key] = elem
+
+	for c.ghostList.Len() > c.ghostMax {
+		back := c.ghostList.Back()
+		c.ghostList.Remove(back)
+		delete(c.ghostCache, back.Value.(string))
+	}
+}
+
+// _evict should only be called with the lock held. It first trims recent down
+// to recentMax, moving evicted keys into the ghost list, then trims frequent
+// (and, in the degenerate case of a maxMem too small for recent alone, recent
+// again) until the cache as a whole fits within maxMem.
+func (c *twoQueue) _evict() {
+	for c.recentMem > c.recentMax && c.recentList.Len() > 0 {
+		back := c.recentList.Back()
+		key := back.Value.(*entry).key
+		c._deleteRecent(key)
+		c._ghostAdd(key)
+		c.evictions++
+	}
+
+	for c.recentMem+c.frequentMem > c.maxMem && c.frequentList.Len() > 0 {
+		back := c.frequentList.Back()
+		c._deleteFrequent(back.Value.(*entry).key)
+		c.evictions++
+	}
+
+	for c.recentMem+c.frequentMem > c.maxMem && c.recentList.Len() > 0 {
+		back := c.recentList.Back()
+		key := back.Value.(*entry).key
+		c._deleteRecent(key)
+		c._ghostAdd(key)
+		c.evictions++
+	}
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTwoQueuePromotesOnSecondAccess(t *testing.T) {
+	tq := newTwoQueue(1024)
+
+	tq.Set("a", []byte("A"), time.Minute)
+
+	_, inFrequent := tq.frequentCache["a"]
+	equals(t, false, inFrequent)
+
+	// First Get after Set is the second access overall, so it promotes.
+	value, ok := tq.Get("a")
+	equals(t, true, ok)
+	equals(t, "A", string(value))
+
+	_, inFrequent = tq.frequentCache["a"]
+	equals(t, true, inFrequent)
+	_, inRecent := tq.recentCache["a"]
+	equals(t, false, inRecent)
+}
+
+func TestTwoQueueGhostPromotesOnReentry(t *testing.T) {
+	tq := newTwoQueue(1024)
+
+	tq.Set("a", []byte("A"), time.Minute)
+
+	// Force "a" out of recent and into the ghost list without ever being
+	// promoted to frequent.
+	tq.recentMax = 0
+	tq._evict()
+
+	_, inRecent := tq.recentCache["a"]
+	equals(t, false, inRecent)
+	_, inGhost := tq.ghostCache["a"]
+	equals(t, true, inGhost)
+
+	// Setting "a" again should skip recent and land directly in frequent.
+	tq.Set("a", []byte("A2"), time.Minute)
+
+	_, inFrequent := tq.frequentCache["a"]
+	equals(t, true, inFrequent)
+	_, inGhost = tq.ghostCache["a"]
+	equals(t, false, inGhost)
+}
+
+func TestTwoQueueExpiration(t *testing.T) {
+	tq := newTwoQueue(1024)
+
+	tq.Set("a", []byte("A"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	_, ok := tq.Get("a")
+	equals(t, false, ok)
+}
+
+func TestTwoQueueStatsAndReset(t *testing.T) {
+	tq := newTwoQueue(1024)
+
+	tq.Set("a", []byte("A"), time.Minute)
+	tq.Get("a")
+	tq.Get("missing")
+
+	stats := tq.Stats()
+	equals(t, 2, stats.Reads)
+	equals(t, 1, stats.Hits)
+	equals(t, 1, stats.Items)
+
+	tq.Reset()
+	equals(t, 0, tq.Stats().Items)
+}
+
+func TestWithTwoQueue(t *testing.T) {
+	builder := New(WithTwoQueue(1024))
+	ctl := builder("")
+
+	_, ok := ctl.store.(*twoQueue)
+	assert(t, ok, "expected WithTwoQueue to install a *twoQueue store, got %T", ctl.store)
+}